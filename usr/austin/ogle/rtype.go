@@ -9,6 +9,7 @@ import (
 	"fmt";
 	"log";
 	"ptrace";
+	"strings";
 )
 
 const debugParseRemoteType = false
@@ -24,16 +25,48 @@ type remoteType struct {
 	// The maker function to turn a remote address of a value of
 	// this type into an interpreter Value.
 	mk maker;
+	// ptrMap holds one bool per pointer-sized word of a value of
+	// this type, true where that word holds a pointer.  It is
+	// derived from the runtime's own gcdata/gcprog for the type (see
+	// ptrMapFor) and is nil for manually-constructed types that have
+	// not been cross-checked against a live remote type.
+	ptrMap []bool;
+	// directIface is true when a value of this type is stored
+	// directly in an interface's data word rather than boxed behind
+	// a pointer to a heap copy, i.e. CommonType.Kind&kindDirectIface
+	// is set for the type.  Only pointers, map/chan/func,
+	// unsafe.Pointer, and single-pointer-field wrappers are direct;
+	// among manually-constructed types only pointer types set it.
+	directIface bool;
+}
+
+// PtrMap returns rt's pointer bitmap, one bool per pointer-sized
+// word, or nil if rt was never parsed from (or validated against) a
+// live remote type.  Callers walking a remoteStruct or remoteArray
+// can use this to avoid dereferencing words that merely look like
+// pointers, such as a scalar that happens to share a pointer's
+// alignment and size.
+func (rt *remoteType) PtrMap() []bool {
+	return rt.ptrMap;
 }
 
 var manualTypes = make(map[Arch] map[eval.Type] *remoteType)
 
+// liveGoTypes caches the most recently parsed live remoteType for
+// each named Go type we've resolved out of a remote process, keyed
+// by that type's name.  newManualType consults it to cross-check a
+// hand-built layout against the runtime's own, via
+// ValidateManualLayout.
+var liveGoTypes = make(map[string] *remoteType)
+
 // newManualType constructs a remote type from an interpreter Type
 // using the size and alignment properties of the given architecture.
 // Most types are parsed directly out of the remote process, but to do
 // so we need to layout the structures that describe those types ourselves.
 func newManualType(t eval.Type, arch Arch) *remoteType {
+	name := "";
 	if nt, ok := t.(*eval.NamedType); ok {
+		name = nt.Name;
 		t = nt.Def;
 	}
 
@@ -49,7 +82,7 @@ func newManualType(t eval.Type, arch Arch) *remoteType {
 			if fieldAlign == 0 {
 				fieldAlign = size;
 			}
-			typeMap[t] = &remoteType{t, size, fieldAlign, mk};
+			typeMap[t] = &remoteType{t, size, fieldAlign, mk, nil, false};
 		};
 		basicType(eval.Uint8Type,   mkUint8,   1, 0);
 		basicType(eval.Uint32Type,  mkUint32,  4, 0);
@@ -70,7 +103,7 @@ func newManualType(t eval.Type, arch Arch) *remoteType {
 		mk := func(r remote) eval.Value {
 			return remotePtr{r, elem};
 		};
-		rt = &remoteType{t, arch.PtrSize(), arch.PtrSize(), mk};
+		rt = &remoteType{t, arch.PtrSize(), arch.PtrSize(), mk, nil, true};
 		// Construct the element type after registering the
 		// type to break cycles.
 		typeMap[t] = rt;
@@ -81,14 +114,14 @@ func newManualType(t eval.Type, arch Arch) *remoteType {
 		mk := func(r remote) eval.Value {
 			return remoteArray{r, t.Len, elem};
 		};
-		rt = &remoteType{t, elem.size*int(t.Len), elem.fieldAlign, mk};
+		rt = &remoteType{t, elem.size*int(t.Len), elem.fieldAlign, mk, nil, false};
 
 	case *eval.SliceType:
 		elem := newManualType(t.Elem, arch);
 		mk := func(r remote) eval.Value {
 			return remoteSlice{r, elem};
 		};
-		rt = &remoteType{t, arch.PtrSize() + 2*arch.IntSize(), arch.PtrSize(), mk};
+		rt = &remoteType{t, arch.PtrSize() + 2*arch.IntSize(), arch.PtrSize(), mk, nil, false};
 
 	case *eval.StructType:
 		layout := make([]remoteStructField, len(t.Elems));
@@ -107,12 +140,22 @@ func newManualType(t eval.Type, arch Arch) *remoteType {
 		mk := func(r remote) eval.Value {
 			return remoteStruct{r, layout};
 		};
-		rt = &remoteType{t, offset, fieldAlign, mk};
+		rt = &remoteType{t, offset, fieldAlign, mk, nil, false};
 
 	default:
 		log.Crashf("cannot manually construct type %T", t);
 	}
 
+	if name != "" {
+		if live, ok := liveGoTypes[name]; ok {
+			if err := ValidateManualLayout(rt, live, arch); err != nil {
+				log.Stderrf("ogle: %v", err);
+			} else {
+				rt.ptrMap = live.ptrMap;
+			}
+		}
+	}
+
 	typeMap[t] = rt;
 	return rt;
 }
@@ -278,7 +321,70 @@ func parseRemoteType(rs remoteStruct) *remoteType {
 			return remoteSlice{r, elem};
 		};
 
-	case p.runtime.PMapType, p.runtime.PChanType, p.runtime.PFuncType, p.runtime.PInterfaceType, p.runtime.PUnsafePointerType, p.runtime.PDotDotDotType:
+	case p.runtime.PMapType:
+		// Cast to a MapType
+		typ := p.runtime.MapType.mk(typ.addr()).(remoteStruct);
+		key := parseRemoteType(typ.Field(p.f.MapType.Key).(remotePtr).Get().(remoteStruct));
+		elem := parseRemoteType(typ.Field(p.f.MapType.Elem).(remotePtr).Get().(remoteStruct));
+		keySize := int(typ.Field(p.f.MapType.KeySize).(remoteUint).Get());
+		valSize := int(typ.Field(p.f.MapType.ValSize).(remoteUint).Get());
+		flags := uint8(typ.Field(p.f.MapType.Flags).(remoteUint).Get());
+		indirectKey := flags&mapFlagIndirectKey != 0;
+		indirectValue := flags&mapFlagIndirectValue != 0;
+		t = eval.NewMapType(key.Type, elem.Type);
+		mk = func(r remote) eval.Value {
+			return remoteMap{r, key, elem, keySize, valSize, indirectKey, indirectValue};
+		};
+
+	case p.runtime.PInterfaceType:
+		// Cast to an InterfaceType
+		typ := p.runtime.InterfaceType.mk(typ.addr()).(remoteStruct);
+		empty := typ.Field(p.f.InterfaceType.Methods).(remoteSlice).Get().Len == 0;
+		t = eval.NewInterfaceType();
+		mk = func(r remote) eval.Value {
+			return remoteInterface{r, empty};
+		};
+
+	case p.runtime.PChanType:
+		// Cast to a ChanType
+		typ := p.runtime.ChanType.mk(typ.addr()).(remoteStruct);
+		elem := parseRemoteType(typ.Field(p.f.ChanType.Elem).(remotePtr).Get().(remoteStruct));
+		dir := eval.ChanDir(typ.Field(p.f.ChanType.Dir).(remoteUint).Get());
+		t = eval.NewChanType(elem.Type, dir);
+		mk = func(r remote) eval.Value {
+			return remoteChan{r, elem};
+		};
+
+	case p.runtime.PFuncType:
+		// Cast to a FuncType
+		typ := p.runtime.FuncType.mk(typ.addr()).(remoteStruct);
+		dotdotdot := typ.Field(p.f.FuncType.DotDotDot).(remoteUint).Get() != 0;
+		inrs := typ.Field(p.f.FuncType.In).(remoteSlice).Get();
+		outrs := typ.Field(p.f.FuncType.Out).(remoteSlice).Get();
+		in := make([]*remoteType, inrs.Len);
+		for i := range in {
+			elemrs := inrs.Base.Elem(int64(i)).(remotePtr).Get().(remoteStruct);
+			in[i] = parseRemoteType(elemrs);
+		}
+		out := make([]*remoteType, outrs.Len);
+		for i := range out {
+			elemrs := outrs.Base.Elem(int64(i)).(remotePtr).Get().(remoteStruct);
+			out[i] = parseRemoteType(elemrs);
+		}
+		inTypes := make([]eval.Type, len(in));
+		for i, rt := range in {
+			inTypes[i] = rt.Type;
+		}
+		outTypes := make([]eval.Type, len(out));
+		for i, rt := range out {
+			outTypes[i] = rt.Type;
+		}
+		t = eval.NewFuncType(inTypes, dotdotdot, outTypes);
+		mk = func(r remote) eval.Value {
+			return remoteFunc{r, in, out};
+		};
+
+	case p.runtime.PUnsafePointerType, p.runtime.PDotDotDotType:
 		// TODO(austin)
 		t = eval.UintptrType;
 		mk = mkUintptr;
@@ -301,6 +407,658 @@ func parseRemoteType(rs remoteStruct) *remoteType {
 	}
 	rt.size = int(typ.Field(p.f.CommonType.Size).(remoteUint).Get());
 	rt.mk = mk;
+	rt.ptrMap = ptrMapFor(typ, p, rt.size);
+	kind := uint8(typ.Field(p.f.CommonType.Kind).(remoteUint).Get());
+	rt.directIface = kind&kindDirectIface != 0;
+
+	if nt != nil {
+		// Remember this live type by name so that, if newManualType
+		// is later asked to hand-build the same Go type for the first
+		// time, it can cross-check its layout against what the remote
+		// process actually uses.  A manual type built before this
+		// point is cached and is not re-checked.
+		liveGoTypes[nt.Name] = rt;
+	}
 
 	return rt;
+}
+
+// kindDirectIface is the bit in a runtime _type's kind field
+// indicating that a value of that type is stored directly in an
+// interface's data word, rather than boxed behind a pointer to a
+// heap copy.  It is set only for types that are themselves
+// pointer-shaped: pointers, map/chan/func, unsafe.Pointer, and
+// single-pointer-field wrappers around them.
+const kindDirectIface = 1 << 5
+
+// kindGCProg is the bit in a runtime _type's kind field indicating
+// that gcdata points to a compressed GC program rather than a
+// literal bitmap.
+const kindGCProg = 1 << 6
+
+// maxGCProgLen bounds how many bytes of a compressed GC program we
+// will read out of the remote process in one go.  Real programs
+// describing even very large types are short, since repeat opcodes
+// make them compress well.
+const maxGCProgLen = 4096
+
+// ptrMapFor computes the pointer bitmap for the runtime type
+// described by typ, which must be a remoteStruct over a CommonType
+// (or a type that embeds one).  The map has one entry per
+// pointer-sized word of a value of size bytes; word i is true if
+// that word holds a pointer, per the type's gcdata.  Words beyond
+// the type's ptrdata (e.g. trailing scalar fields) are always false,
+// since the runtime never walks past ptrdata when scanning.
+func ptrMapFor(typ remoteStruct, p *process, size int) []bool {
+	ptrSize := p.arch.PtrSize();
+	nwords := (size + ptrSize - 1) / ptrSize;
+	bits := make([]bool, nwords);
+
+	gcdata := ptrace.Word(mkUintptr(typ.Field(p.f.CommonType.GCData).(remotePtr).r).(remoteUint).Get());
+	ptrdata := int(typ.Field(p.f.CommonType.PtrData).(remoteUint).Get());
+	progWords := (ptrdata + ptrSize - 1) / ptrSize;
+	if gcdata == 0 || progWords == 0 {
+		return bits;
+	}
+
+	kind := uint8(typ.Field(p.f.CommonType.Kind).(remoteUint).Get());
+	if kind&kindGCProg == 0 {
+		// A literal bitmap: one bit per word, packed low-to-high
+		// within each byte, covering the pointerful prefix.
+		nbytes := (progWords + 7) / 8;
+		raw := p.peek(gcdata, nbytes);
+		for w := 0; w < progWords; w++ {
+			if raw[w/8]&(1<<uint(w%8)) != 0 {
+				bits[w] = true;
+			}
+		}
+		return bits;
+	}
+
+	raw := p.peek(gcdata, maxGCProgLen);
+	copy(bits, decodeGCProg(raw, progWords));
+	return bits;
+}
+
+// decodeGCProg decodes a compressed GC program into a []bool
+// pointer bitmap of exactly nbits entries.  The program is a
+// sequence of variable-length instructions:
+//
+//	0x00                        end of program
+//	0x01 <n varint> <ceil(n/8) bytes>
+//	                             emit n literal bits, packed
+//	                             low-to-high, with the final byte
+//	                             padded with zero bits
+//	0x02 <n varint> <m varint>  repeat the previous n decoded bits,
+//	                             m more times
+//
+// Varints are base-128, little-endian, with the high bit of each
+// byte as the continuation flag.
+func decodeGCProg(prog []byte, nbits int) []bool {
+	bits := make([]bool, 0, nbits);
+	pos := 0;
+	readVarint := func() int {
+		v, shift := 0, uint(0);
+		for {
+			b := prog[pos];
+			pos++;
+			v |= int(b&0x7f) << shift;
+			if b&0x80 == 0 {
+				break;
+			}
+			shift += 7;
+		}
+		return v;
+	};
+
+	for pos < len(prog) && len(bits) < nbits {
+		op := prog[pos];
+		pos++;
+		switch op {
+		case 0x00:
+			pos = len(prog);
+
+		case 0x01:
+			n := readVarint();
+			nbytes := (n + 7) / 8;
+			if pos+nbytes > len(prog) {
+				eval.Abort(FormatError(fmt.Sprintf("malformed GC program: literal of %d bits overruns %d-byte program", n, len(prog))));
+			}
+			for i := 0; i < n && len(bits) < nbits; i++ {
+				bits = append(bits, prog[pos+i/8]&(1<<uint(i%8)) != 0);
+			}
+			pos += nbytes;
+
+		case 0x02:
+			n := readVarint();
+			m := readVarint();
+			if n > len(bits) {
+				eval.Abort(FormatError(fmt.Sprintf("malformed GC program: repeat count %d exceeds %d decoded bits", n, len(bits))));
+			}
+			pattern := make([]bool, n);
+			copy(pattern, bits[len(bits)-n:]);
+			for r := 0; r < m && len(bits) < nbits; r++ {
+				for _, b := range pattern {
+					if len(bits) >= nbits {
+						break;
+					}
+					bits = append(bits, b);
+				}
+			}
+
+		default:
+			eval.Abort(FormatError(fmt.Sprintf("unexpected GC program opcode %#x", op)));
+		}
+	}
+	for len(bits) < nbits {
+		bits = append(bits, false);
+	}
+	return bits[0:nbits];
+}
+
+// manualPtrMap computes the pointer bitmap implied by the shape of a
+// manually-constructed remoteType (see newManualType), without any
+// access to a remote process.  It is the counterpart compared
+// against a live type's runtime-derived PtrMap in
+// ValidateManualLayout.
+func manualPtrMap(rt *remoteType, arch Arch) []bool {
+	ptrSize := arch.PtrSize();
+	nwords := (rt.size + ptrSize - 1) / ptrSize;
+	bits := make([]bool, nwords);
+
+	if rt.Type == eval.StringType.(*eval.NamedType).Def {
+		bits[0] = true;
+		return bits;
+	}
+
+	switch t := rt.Type.(type) {
+	case *eval.PtrType:
+		bits[0] = true;
+
+	case *eval.SliceType:
+		bits[0] = true;
+
+	case *eval.ArrayType:
+		elem := newManualType(t.Elem, arch);
+		elemWords := elem.size / ptrSize;
+		elemBits := manualPtrMap(elem, arch);
+		for i := int64(0); i < t.Len; i++ {
+			copy(bits[int64(elemWords)*i:], elemBits);
+		}
+
+	case *eval.StructType:
+		offset := 0;
+		for _, f := range t.Elems {
+			fieldType := newManualType(f.Type, arch);
+			offset = arch.Align(offset, fieldType.fieldAlign);
+			copy(bits[offset/ptrSize:], manualPtrMap(fieldType, arch));
+			offset += fieldType.size;
+		}
+	}
+
+	return bits;
+}
+
+// ValidateManualLayout cross-checks a manually-constructed struct
+// layout (see newManualType) against the runtime's authoritative
+// pointer bitmap for the same Go type, as read from a live remote
+// _type.  It exists because the offsets newManualType derives are
+// reconstructed independently of what the runtime actually lays
+// down, which has drifted before across Go versions.  newManualType
+// calls this itself, against liveGoTypes, when it first constructs a
+// named type for which a live remote type of the same name has
+// already been parsed; a mismatch is logged rather than
+// aborting the debugging session, since the manual layout may still
+// work for the types actually in use.
+func ValidateManualLayout(mt *remoteType, live *remoteType, arch Arch) error {
+	if mt.size != live.size {
+		return FormatError(fmt.Sprintf("manual layout size %d disagrees with runtime size %d for %s", mt.size, live.size, mt.Type));
+	}
+	want := live.ptrMap;
+	got := manualPtrMap(mt, arch);
+	if len(got) != len(want) {
+		return FormatError(fmt.Sprintf("manual and runtime pointer maps have different lengths for %s", mt.Type));
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			return FormatError(fmt.Sprintf("%s: word %d: manual layout says pointer=%v, runtime says %v", mt.Type, i, got[i], want[i]));
+		}
+	}
+	return nil;
+}
+
+// bucketCnt is the number of key/value slots in a single runtime
+// bucket (bmap).  This mirrors the runtime's BUCKETSIZE constant.
+const bucketCnt = 8
+
+// Bits in a MapType's flags field indicating that keys, or values,
+// larger than a pointer are stored indirectly: the bucket slot holds
+// a pointer to an out-of-line copy rather than the value itself.
+const (
+	mapFlagIndirectKey   = 1 << 0;
+	mapFlagIndirectValue = 1 << 1;
+)
+
+// Tophash sentinel values the runtime stores in a cell in place of a
+// real top-8-bits-of-hash value.  A cell whose tophash is below
+// minTopHash is either empty or has already been evacuated to a
+// newer table during an incremental grow, and must be skipped.
+const (
+	emptyRest      = 0;
+	emptyOne       = 1;
+	evacuatedX     = 2;
+	evacuatedY     = 3;
+	evacuatedEmpty = 4;
+	minTopHash     = 5;
+)
+
+// A remoteMap is a map value in a remote process.  It is represented
+// as a pointer to an hmap header, which in turn owns an array of
+// bmap buckets (plus, during growth, an old array being evacuated).
+type remoteMap struct {
+	r remote;
+	keyType, elemType *remoteType;
+	// keySize and valSize are the slot sizes used in the bucket
+	// array, which equal keyType.size/elemType.size unless the
+	// MapType's indirectkey/indirectvalue flags say the key or
+	// value is stored as a pointer to an out-of-line copy.
+	keySize, valSize int;
+	indirectKey, indirectValue bool;
+}
+
+// hmap describes the layout of the runtime's map header that we read
+// out of the remote process.
+type hmap struct {
+	count int64;
+	b uint;
+	hash0 uint32;
+	buckets, oldbuckets remote;
+	valid bool;
+}
+
+func (m remoteMap) header() hmap {
+	p := m.r.p;
+	addr := ptrace.Word(mkUintptr(m.r).(remoteUint).Get());
+	if addr == 0 {
+		return hmap{};
+	}
+	hs := p.runtime.Hmap.mk(remote{addr, p}).(remoteStruct);
+	h := hmap{
+		count: int64(hs.Field(p.f.Hmap.Count).(remoteUint).Get()),
+		b: uint(hs.Field(p.f.Hmap.B).(remoteUint).Get()),
+		hash0: uint32(hs.Field(p.f.Hmap.Hash0).(remoteUint).Get()),
+		valid: true,
+	};
+	if bp := hs.Field(p.f.Hmap.Buckets).(remotePtr); true {
+		h.buckets = remote{ptrace.Word(mkUintptr(bp.r).(remoteUint).Get()), p};
+	}
+	if op := hs.Field(p.f.Hmap.OldBuckets).(remotePtr); true {
+		h.oldbuckets = remote{ptrace.Word(mkUintptr(op.r).(remoteUint).Get()), p};
+	}
+	return h;
+}
+
+// Len returns the number of entries in the map.
+func (m remoteMap) Len() int64 {
+	return m.header().count;
+}
+
+// bucketSize returns the size in bytes of one bmap, given the
+// (possibly indirect) key and value slot sizes.
+func (m remoteMap) bucketSize() int {
+	return bucketCnt + bucketCnt*m.keySize + bucketCnt*m.valSize + m.r.p.arch.PtrSize();
+}
+
+// cellOffsets returns the byte offsets, within a bucket, of the
+// tophash byte and the key and value slots for cell i.  keySize and
+// valSize are slot sizes: equal to the key/value type's own size
+// unless stored indirectly, in which case they're a pointer size and
+// the caller must chase one more pointer to reach the real value.
+func cellOffsets(i, keySize, valSize int) (tophashOff, keyOff, valOff int) {
+	tophashOff = i;
+	keyOff = bucketCnt + i*keySize;
+	valOff = bucketCnt + bucketCnt*keySize + i*valSize;
+	return;
+}
+
+// cellValue reads the key/value pair out of cell i of the bucket at
+// addr, or returns ok == false if the cell is empty or has already
+// been evacuated to a newer table (see the minTopHash comment).
+func (m remoteMap) cellValue(addr remote, i int) (key, val eval.Value, ok bool) {
+	p := addr.p;
+	tophashOff, keyOff, valOff := cellOffsets(i, m.keySize, m.valSize);
+	tophash := p.peek(addr.base+ptrace.Word(tophashOff), 1)[0];
+	if tophash < minTopHash {
+		return;
+	}
+	keyAddr := remote{addr.base + ptrace.Word(keyOff), p};
+	valAddr := remote{addr.base + ptrace.Word(valOff), p};
+	if m.indirectKey {
+		keyAddr = remote{ptrace.Word(mkUintptr(keyAddr).(remoteUint).Get()), p};
+	}
+	if m.indirectValue {
+		valAddr = remote{ptrace.Word(mkUintptr(valAddr).(remoteUint).Get()), p};
+	}
+	key = m.keyType.mk(keyAddr);
+	val = m.elemType.mk(valAddr);
+	ok = true;
+	return;
+}
+
+// overflow returns the overflow bucket linked from the bucket at
+// addr, or the zero remote if there is none.
+func (m remoteMap) overflow(addr remote) remote {
+	p := addr.p;
+	off := ptrace.Word(bucketCnt + bucketCnt*m.keySize + bucketCnt*m.valSize);
+	next := ptrace.Word(mkUintptr(remote{addr.base + off, p}).(remoteUint).Get());
+	if next == 0 {
+		return remote{};
+	}
+	return remote{next, p};
+}
+
+// Iter calls f for every (key, value) pair currently stored in the
+// map, including any entries still reachable through an in-progress
+// incremental grow via oldbuckets.
+func (m remoteMap) Iter(f func(key, val eval.Value) bool) {
+	h := m.header();
+	if !h.valid {
+		return;
+	}
+	bsize := ptrace.Word(m.bucketSize());
+	walk := func(base remote, nbuckets int64) bool {
+		if base.base == 0 {
+			return true;
+		}
+		for bi := int64(0); bi < nbuckets; bi++ {
+			b := remote{base.base + bsize*ptrace.Word(bi), base.p};
+			for b.base != 0 {
+				for i := 0; i < bucketCnt; i++ {
+					key, val, ok := m.cellValue(b, i);
+					if ok && !f(key, val) {
+						return false;
+					}
+				}
+				b = m.overflow(b);
+			}
+		}
+		return true;
+	};
+	// While an incremental grow is in progress, oldbuckets is half
+	// the size of the live bucket array (the runtime only ever
+	// doubles), and any cell the runtime has already moved out of an
+	// old bucket carries an evacuated tophash that cellValue skips,
+	// so a cell is never yielded from both the old and new arrays.
+	oldBucketCount := int64(0);
+	if h.oldbuckets.base != 0 && h.b > 0 {
+		oldBucketCount = int64(1) << (h.b - 1);
+	}
+	if !walk(h.oldbuckets, oldBucketCount) {
+		return;
+	}
+	walk(h.buckets, int64(1)<<h.b);
+}
+
+// A remoteInterface is an interface value in a remote process.  r
+// addresses the two-word iface/eface representation: a tab/type word
+// followed by a data word.  empty is true for eface (interface{})
+// values, which store a *_type directly in the first word rather
+// than an *itab.
+type remoteInterface struct {
+	r remote;
+	empty bool;
+}
+
+// itabType follows an itab pointer to the concrete *_type it
+// describes, resolving and caching the corresponding remoteType.
+// Parsed itabs are cached in p.types keyed by the itab's own
+// address, so that a second interface value sharing the same
+// dynamic (concrete type, interface type) pair is resolved without
+// re-walking the itab's method table.
+func itabType(p *process, itab ptrace.Word) *remoteType {
+	if rt, ok := p.types[itab]; ok && rt.Type != nil {
+		return rt;
+	}
+	ts := p.runtime.Itab.mk(remote{itab, p}).(remoteStruct);
+	typrs := ts.Field(p.f.Itab.Type).(remotePtr).Get().(remoteStruct);
+	rt := parseRemoteType(typrs);
+	p.types[itab] = rt;
+	return rt;
+}
+
+// concrete returns the dynamic remoteType of v and the remote
+// location of its data, auto-dereferencing the data word unless the
+// concrete type is pointer-shaped (rt.directIface), in which case
+// the interface stores the value directly rather than a pointer to
+// a heap copy of it.
+func (v remoteInterface) concrete() (rt *remoteType, data remote) {
+	p := v.r.p;
+	ptrSize := p.arch.PtrSize();
+	var typeWord ptrace.Word;
+	if v.empty {
+		typeWord = ptrace.Word(mkUintptr(v.r).(remoteUint).Get());
+		if typeWord == 0 {
+			return nil, remote{};
+		}
+		typrs := p.runtime.Type.mk(remote{typeWord, p}).(remoteStruct);
+		rt = parseRemoteType(typrs);
+	} else {
+		itab := ptrace.Word(mkUintptr(v.r).(remoteUint).Get());
+		if itab == 0 {
+			return nil, remote{};
+		}
+		rt = itabType(p, itab);
+	}
+	dataAddr := remote{v.r.base + ptrace.Word(ptrSize), p};
+	if !rt.directIface {
+		dataAddr = remote{ptrace.Word(mkUintptr(dataAddr).(remoteUint).Get()), p};
+	}
+	return rt, dataAddr;
+}
+
+// Type returns the dynamic type currently stored in the interface,
+// or nil for a nil interface value.
+func (v remoteInterface) Type() eval.Type {
+	rt, _ := v.concrete();
+	if rt == nil {
+		return nil;
+	}
+	return rt.Type;
+}
+
+// Elem returns the concrete value currently stored in the interface,
+// or nil for a nil interface value.
+func (v remoteInterface) Elem() eval.Value {
+	rt, data := v.concrete();
+	if rt == nil {
+		return nil;
+	}
+	return rt.mk(data);
+}
+
+// A remoteChan is a channel value in a remote process.  r addresses
+// the channel pointer word; the channel header itself (an hchan) is
+// read lazily from the address that word points to.
+type remoteChan struct {
+	r remote;
+	elemType *remoteType;
+}
+
+// hchan describes the layout of the runtime's channel header.
+type hchan struct {
+	qcount, dataqsiz, recvx int64;
+	buf remote;
+	closed bool;
+	recvqLen, sendqLen int64;
+	valid bool;
+}
+
+// waitqLen counts the sudogs linked from a waitq, an intrusive
+// linked list of blocked goroutines (first/last *sudog, each with
+// its own next pointer) rather than a struct with a stored count.
+func waitqLen(wq remoteStruct, p *process) int64 {
+	n := int64(0);
+	next := ptrace.Word(mkUintptr(wq.Field(p.f.Waitq.First).(remotePtr).r).(remoteUint).Get());
+	for next != 0 {
+		n++;
+		sg := p.runtime.Sudog.mk(remote{next, p}).(remoteStruct);
+		next = ptrace.Word(mkUintptr(sg.Field(p.f.Sudog.Next).(remotePtr).r).(remoteUint).Get());
+	}
+	return n;
+}
+
+func (c remoteChan) header() hchan {
+	p := c.r.p;
+	addr := ptrace.Word(mkUintptr(c.r).(remoteUint).Get());
+	if addr == 0 {
+		return hchan{};
+	}
+	hs := p.runtime.Hchan.mk(remote{addr, p}).(remoteStruct);
+	return hchan{
+		qcount: int64(hs.Field(p.f.Hchan.Qcount).(remoteUint).Get()),
+		dataqsiz: int64(hs.Field(p.f.Hchan.Dataqsiz).(remoteUint).Get()),
+		recvx: int64(hs.Field(p.f.Hchan.Recvx).(remoteUint).Get()),
+		buf: remote{ptrace.Word(mkUintptr(hs.Field(p.f.Hchan.Buf).(remotePtr).r).(remoteUint).Get()), p},
+		closed: hs.Field(p.f.Hchan.Closed).(remoteUint).Get() != 0,
+		recvqLen: waitqLen(hs.Field(p.f.Hchan.Recvq).(remoteStruct), p),
+		sendqLen: waitqLen(hs.Field(p.f.Hchan.Sendq).(remoteStruct), p),
+		valid: true,
+	};
+}
+
+// Len returns the number of elements currently buffered in the
+// channel.
+func (c remoteChan) Len() int64 {
+	return c.header().qcount;
+}
+
+// Cap returns the channel's buffer capacity.
+func (c remoteChan) Cap() int64 {
+	return c.header().dataqsiz;
+}
+
+// Closed reports whether the channel has been closed.
+func (c remoteChan) Closed() bool {
+	return c.header().closed;
+}
+
+// Waiting returns the number of goroutines blocked receiving from
+// and sending to the channel, respectively.  Each sudog queue is
+// walked only far enough to count its entries; the individual
+// blocked goroutines are not otherwise presented.
+func (c remoteChan) Waiting() (recv, send int64) {
+	h := c.header();
+	return h.recvqLen, h.sendqLen;
+}
+
+// Elem returns the i'th buffered element in receive order, where
+// 0 <= i < c.Len().  Buffered elements form a ring starting at
+// recvx, so the logical index is translated into that ring.
+func (c remoteChan) Elem(i int64) eval.Value {
+	h := c.header();
+	slot := (h.recvx + i) % h.dataqsiz;
+	addr := remote{h.buf.base + ptrace.Word(slot*int64(c.elemType.size)), c.r.p};
+	return c.elemType.mk(addr);
+}
+
+// A remoteFunc is a func value in a remote process.  A non-nil func
+// value is a pointer to a closure record whose first word is the
+// entry PC; any captured variables follow that word.
+type remoteFunc struct {
+	r remote;
+	in, out []*remoteType;
+}
+
+// entry returns the closure's entry PC, or 0 for a nil func value.
+func (f remoteFunc) entry() ptrace.Word {
+	addr := ptrace.Word(mkUintptr(f.r).(remoteUint).Get());
+	if addr == 0 {
+		return 0;
+	}
+	p := f.r.p;
+	return ptrace.Word(mkUintptr(remote{addr, p}).(remoteUint).Get());
+}
+
+// Captured returns the i'th variable captured by the closure, read
+// from the closure record immediately following the entry PC word.
+// Captured variable types are recovered from DWARF, when available,
+// rather than from the FuncType (which only describes parameters
+// and results); if DWARF info for the function isn't available,
+// Captured returns nil.
+func (f remoteFunc) Captured(i int) eval.Value {
+	p := f.r.p;
+	addr := ptrace.Word(mkUintptr(f.r).(remoteUint).Get());
+	if addr == 0 {
+		return nil;
+	}
+	pc := f.entry();
+	// Captured variables aren't fixed-size, and the compiler aligns
+	// each one to its own field alignment, so variable i's offset is
+	// not simply i times its size or a straight sum of the preceding
+	// sizes: it's the same align-then-add layout newManualType uses
+	// for StructType fields.
+	off := p.arch.PtrSize();
+	for j := 0; j < i; j++ {
+		prev := p.dwarfClosureVar(pc, j);
+		if prev == nil {
+			return nil;
+		}
+		off = p.arch.Align(off, prev.fieldAlign);
+		off += prev.size;
+	}
+	rt := p.dwarfClosureVar(pc, i);
+	if rt == nil {
+		return nil;
+	}
+	off = p.arch.Align(off, rt.fieldAlign);
+	return rt.mk(remote{addr + ptrace.Word(off), p});
+}
+
+// String formats f as Go source would print a function value,
+// resolving the entry PC to a symbol name, e.g.
+// "main.foo(int, string) error at 0x4012a0".
+func (f remoteFunc) String() string {
+	pc := f.entry();
+	if pc == 0 {
+		return "<nil>";
+	}
+	p := f.r.p;
+	name := "<unknown>";
+	if sym := p.syms.SymFromAddr(uint64(pc)); sym != nil {
+		name = sym.Common().Name;
+	}
+	ins := make([]string, len(f.in));
+	for i, rt := range f.in {
+		ins[i] = rt.Type.String();
+	}
+	outs := make([]string, len(f.out));
+	for i, rt := range f.out {
+		outs[i] = rt.Type.String();
+	}
+	sig := "(" + strings.Join(ins, ", ") + ")";
+	if len(outs) == 1 {
+		sig += " " + outs[0];
+	} else if len(outs) > 1 {
+		sig += " (" + strings.Join(outs, ", ") + ")";
+	}
+	return fmt.Sprintf("%s%s at %#x", name, sig, uint64(pc));
+}
+
+// Get looks up key in the map by scanning every entry via Iter.  The
+// runtime's own hash algorithm is AES-based on amd64 and otherwise
+// machine- and version-specific, so there's no way to compute it
+// here well enough to go straight to the right bucket; a full scan
+// is the only honest option.
+func (m remoteMap) Get(key eval.Value) (eval.Value, bool) {
+	var found eval.Value;
+	ok := false;
+	m.Iter(func(k, v eval.Value) bool {
+		if eval.ValuesEqual(k, key) {
+			found = v;
+			ok = true;
+			return false;
+		}
+		return true;
+	});
+	return found, ok;
 }
\ No newline at end of file
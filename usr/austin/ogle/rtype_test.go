@@ -0,0 +1,71 @@
+// Copyright 2009 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ogle
+
+import (
+	"testing";
+)
+
+func TestCellOffsets(t *testing.T) {
+	// 8-byte keys, 4-byte values: matches a map[int64]int32 bucket.
+	const keySize, valSize = 8, 4;
+	for i := 0; i < bucketCnt; i++ {
+		tophashOff, keyOff, valOff := cellOffsets(i, keySize, valSize);
+		if tophashOff != i {
+			t.Errorf("cell %d: tophashOff = %d, want %d", i, tophashOff, i);
+		}
+		wantKeyOff := bucketCnt + i*keySize;
+		if keyOff != wantKeyOff {
+			t.Errorf("cell %d: keyOff = %d, want %d", i, keyOff, wantKeyOff);
+		}
+		wantValOff := bucketCnt + bucketCnt*keySize + i*valSize;
+		if valOff != wantValOff {
+			t.Errorf("cell %d: valOff = %d, want %d", i, valOff, wantValOff);
+		}
+	}
+
+	// Cells must not overlap: cell 1's key must immediately follow
+	// cell 0's, and the value array must start after the whole key
+	// array.
+	_, key0, val0 := cellOffsets(0, keySize, valSize);
+	_, key1, _ := cellOffsets(1, keySize, valSize);
+	if key1 != key0+keySize {
+		t.Errorf("cell 1 key offset %d does not immediately follow cell 0 (key %d, size %d)", key1, key0, keySize);
+	}
+	if val0 < key0+bucketCnt*keySize {
+		t.Errorf("value array at %d overlaps the key array starting at %d", val0, key0);
+	}
+}
+
+func TestDecodeGCProg(t *testing.T) {
+	// Literal: two bits, 1 then 0, padded out to a whole byte.
+	got := decodeGCProg([]byte{0x01, 2, 0x01, 0x00}, 2);
+	want := []bool{true, false};
+	for i, w := range want {
+		if got[i] != w {
+			t.Errorf("literal: bit %d = %v, want %v", i, got[i], w);
+		}
+	}
+
+	// Repeat: one literal bit (true), repeated 3 more times, for 4
+	// bits total.
+	got = decodeGCProg([]byte{0x01, 1, 0x01, 0x02, 1, 3, 0x00}, 4);
+	for i, w := range []bool{true, true, true, true} {
+		if got[i] != w {
+			t.Errorf("repeat: bit %d = %v, want %v", i, got[i], w);
+		}
+	}
+
+	// Programs shorter than nbits are zero-padded.
+	got = decodeGCProg([]byte{0x00}, 3);
+	if len(got) != 3 {
+		t.Fatalf("decodeGCProg returned %d bits, want 3", len(got));
+	}
+	for i, b := range got {
+		if b {
+			t.Errorf("padding: bit %d = true, want false", i);
+		}
+	}
+}